@@ -0,0 +1,80 @@
+// Package nodes holds the task-graph node types that identify a unit of work
+// within a turbo run.
+package nodes
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vercel/turbo/cli/internal/turbopath"
+)
+
+// TaskDefinition captures the subset of a task's turbo.json configuration
+// that execution needs once the task graph has already been resolved.
+type TaskDefinition struct {
+	// Timeout is how long the task is allowed to run before it's terminated.
+	// The zero value means no timeout.
+	Timeout time.Duration
+	// KillTimeout is the grace period between asking the task to shut down
+	// gracefully and escalating to a kill, once Timeout has elapsed. The zero
+	// value means the caller's default should be used.
+	KillTimeout time.Duration
+}
+
+// packageInfo is the subset of a package's manifest a task needs in order to
+// run: where it lives on disk.
+type packageInfo struct {
+	Dir turbopath.AnchoredSystemPath
+}
+
+// NewTaskDefinition parses the `timeout`/`killTimeout` fields of a task's
+// turbo.json entry into a TaskDefinition. Both are optional duration strings
+// (e.g. "5m", "30s"); an empty string leaves the corresponding field at its
+// zero value rather than being an error.
+//
+// This is the parsing half of turbo.json's timeout support -- the pipeline
+// loader that reads turbo.json and builds each PackageTask (outside this
+// package) is expected to call it once per task definition and attach the
+// result as that PackageTask's TaskDefinition.
+func NewTaskDefinition(rawTimeout string, rawKillTimeout string) (TaskDefinition, error) {
+	var def TaskDefinition
+
+	if rawTimeout != "" {
+		timeout, err := time.ParseDuration(rawTimeout)
+		if err != nil {
+			return TaskDefinition{}, fmt.Errorf("invalid task timeout %q: %w", rawTimeout, err)
+		}
+		def.Timeout = timeout
+	}
+
+	if rawKillTimeout != "" {
+		killTimeout, err := time.ParseDuration(rawKillTimeout)
+		if err != nil {
+			return TaskDefinition{}, fmt.Errorf("invalid task killTimeout %q: %w", rawKillTimeout, err)
+		}
+		def.KillTimeout = killTimeout
+	}
+
+	return def, nil
+}
+
+// PackageTask is a single task bound to the package it runs in.
+type PackageTask struct {
+	TaskID         string
+	Task           string
+	PackageName    string
+	Command        string
+	Hash           string
+	Pkg            *packageInfo
+	TaskDefinition TaskDefinition
+}
+
+// OutputPrefix returns the prefix used when printing this task's output. In
+// single-package mode the task name alone is enough; otherwise it's
+// qualified by package name.
+func (pt *PackageTask) OutputPrefix(isSinglePackage bool) string {
+	if isSinglePackage {
+		return pt.Task
+	}
+	return fmt.Sprintf("%s:%s", pt.PackageName, pt.Task)
+}
@@ -0,0 +1,34 @@
+package nodes
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNewTaskDefinition checks the turbo.json timeout/killTimeout strings
+// parse into the durations execContext.exec reads off TaskDefinition, and
+// that leaving either field unset doesn't error.
+func TestNewTaskDefinition(t *testing.T) {
+	def, err := NewTaskDefinition("5m", "30s")
+	if err != nil {
+		t.Fatalf("NewTaskDefinition: %v", err)
+	}
+	if def.Timeout != 5*time.Minute {
+		t.Errorf("Timeout = %v, want %v", def.Timeout, 5*time.Minute)
+	}
+	if def.KillTimeout != 30*time.Second {
+		t.Errorf("KillTimeout = %v, want %v", def.KillTimeout, 30*time.Second)
+	}
+
+	def, err = NewTaskDefinition("", "")
+	if err != nil {
+		t.Fatalf("NewTaskDefinition: %v", err)
+	}
+	if def.Timeout != 0 || def.KillTimeout != 0 {
+		t.Errorf("expected zero-value TaskDefinition, got %+v", def)
+	}
+
+	if _, err := NewTaskDefinition("not-a-duration", ""); err == nil {
+		t.Error("expected an error for an unparseable timeout, got nil")
+	}
+}
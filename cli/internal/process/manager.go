@@ -0,0 +1,138 @@
+// Package process supervises the external commands a turbo run starts, so
+// that a single Ctrl-C (or a forceful shutdown) can tear all of them down
+// together.
+package process
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrClosing is returned by Exec and ExecWithTimeout once the Manager has
+// started shutting down; no new process may be started after that point.
+var ErrClosing = errors.New("the process manager is closing; no new processes may be started")
+
+// ChildExit wraps a child process's exit code as an error, so callers can
+// distinguish "ran and exited non-zero" from other kinds of failures.
+type ChildExit struct {
+	ExitCode int
+}
+
+func (c *ChildExit) Error() string {
+	return fmt.Sprintf("command finished with exit code %v", c.ExitCode)
+}
+
+// Manager supervises every child process a run starts, so they can all be
+// killed together on a forceful shutdown.
+type Manager struct {
+	mu       sync.Mutex
+	children map[*exec.Cmd]struct{}
+	closing  bool
+}
+
+// NewManager returns a Manager ready to supervise child processes.
+func NewManager() *Manager {
+	return &Manager{children: make(map[*exec.Cmd]struct{})}
+}
+
+func (m *Manager) addChild(cmd *exec.Cmd) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closing {
+		return ErrClosing
+	}
+	m.children[cmd] = struct{}{}
+	return nil
+}
+
+func (m *Manager) removeChild(cmd *exec.Cmd) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.children, cmd)
+}
+
+// Exec starts cmd and waits for it to finish.
+func (m *Manager) Exec(cmd *exec.Cmd) error {
+	if err := m.addChild(cmd); err != nil {
+		return err
+	}
+	defer m.removeChild(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return waitChildExit(cmd)
+}
+
+// ExecWithTimeout starts cmd and waits for it to finish, racing it against
+// ctx. If ctx is done before the command finishes on its own, the child is
+// sent a graceful termination signal (see terminate, which is
+// platform-specific and sends nothing on Windows); if it hasn't exited within
+// killTimeout, it's killed outright. killTimeout <= 0 kills the process right
+// after the termination signal, without waiting for it to exit.
+func (m *Manager) ExecWithTimeout(ctx context.Context, cmd *exec.Cmd, killTimeout time.Duration) error {
+	if err := m.addChild(cmd); err != nil {
+		return err
+	}
+	defer m.removeChild(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- waitChildExit(cmd) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		terminate(cmd)
+		if killTimeout <= 0 {
+			_ = cmd.Process.Kill()
+			return <-done
+		}
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(killTimeout):
+			_ = cmd.Process.Kill()
+			return <-done
+		}
+	}
+}
+
+func waitChildExit(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ChildExit{ExitCode: exitErr.ExitCode()}
+	}
+	return err
+}
+
+// Close kills every process currently being supervised and prevents any new
+// one from starting.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	m.closing = true
+	children := make([]*exec.Cmd, 0, len(m.children))
+	for cmd := range m.children {
+		children = append(children, cmd)
+	}
+	m.mu.Unlock()
+
+	for _, cmd := range children {
+		if cmd.Process != nil {
+			_ = cmd.Process.Kill()
+		}
+	}
+}
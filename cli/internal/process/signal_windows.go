@@ -0,0 +1,13 @@
+//go:build windows
+
+package process
+
+import (
+	"os/exec"
+)
+
+// terminate sends nothing on Windows: os.Process.Signal there supports only
+// Kill, so there is no graceful signal to deliver. A child that outlives its
+// timeout keeps running until ExecWithTimeout kills it once killTimeout has
+// elapsed.
+func terminate(cmd *exec.Cmd) {}
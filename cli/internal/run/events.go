@@ -0,0 +1,308 @@
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/vercel/turbo/cli/internal/logstreamer"
+)
+
+// EventType identifies the kind of thing that happened during a run.
+type EventType string
+
+// The well-defined points in a run's lifecycle that get published to the
+// configured RunEventSink.
+const (
+	EventTaskQueued   EventType = "task_queued"
+	EventTaskStarted  EventType = "task_started"
+	EventCacheHit     EventType = "cache_hit"
+	EventCacheMiss    EventType = "cache_miss"
+	EventTaskOutput   EventType = "task_output"
+	EventTaskFinished EventType = "task_finished"
+	EventRunFinished  EventType = "run_finished"
+)
+
+// Event is a single point-in-time occurrence during a run, published to a
+// RunEventSink. Not every field is populated for every EventType; e.g. Stream
+// and Data are only set on EventTaskOutput.
+type Event struct {
+	Type      EventType `json:"type"`
+	TaskID    string    `json:"taskId,omitempty"`
+	Stream    string    `json:"stream,omitempty"`
+	Data      string    `json:"data,omitempty"`
+	ExitCode  int       `json:"exitCode,omitempty"`
+	Duration  string    `json:"duration,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// RunEventSink receives Events published by RealRun and execContext.exec as a
+// run progresses. This lets external consumers (IDE integrations, CI
+// dashboards, log aggregators) observe a run without scraping colorized
+// terminal output.
+type RunEventSink interface {
+	Emit(Event)
+}
+
+// noopEventSink discards every event. It stands in for the real event sink
+// in tests that exercise execContext without caring where output goes.
+type noopEventSink struct{}
+
+func (noopEventSink) Emit(Event) {}
+
+// uiTaskStreams holds the pair of logstreamers a single task's stdout/stderr
+// are replayed through while it's running.
+type uiTaskStreams struct {
+	out *logstreamer.Logstreamer
+	err *logstreamer.Logstreamer
+}
+
+// uiEventSink renders a run's output to the terminal through the existing
+// prefixed, colorized logstreamer machinery. It's registered into the event
+// fan-out the same way --output-logs=json-stream and --event-webhook are, so
+// the pretty UI is one RunEventSink among several rather than a path that's
+// hard-coded separately from the event system. It's always included,
+// regardless of flags, since some form of terminal output is the default.
+//
+// Unlike the other sinks, it needs to know a task's logger and prefix before
+// it can do anything with that task's EventTaskOutput events -- register and
+// unregister exist for execContext.exec to provide and reclaim that state
+// around a task's lifetime.
+type uiEventSink struct {
+	mu    sync.Mutex
+	tasks map[string]*uiTaskStreams
+}
+
+func newUIEventSink() *uiEventSink {
+	return &uiEventSink{tasks: make(map[string]*uiTaskStreams)}
+}
+
+// register creates the logstreamers a task's output should be replayed
+// through and makes them visible to Emit. It must be called before the
+// task's command starts writing, and its result closed and reclaimed via
+// unregister once the command finishes.
+func (s *uiEventSink) register(taskID string, logger *log.Logger, prettyPrefix string) *uiTaskStreams {
+	streams := &uiTaskStreams{
+		out: logstreamer.NewLogstreamer(logger, prettyPrefix, false),
+		err: logstreamer.NewLogstreamer(logger, prettyPrefix, false),
+	}
+	// Flush/Reset any error recorded on these streamers before the command
+	// starts writing to them.
+	streams.out.FlushRecord()
+	streams.err.FlushRecord()
+
+	s.mu.Lock()
+	s.tasks[taskID] = streams
+	s.mu.Unlock()
+	return streams
+}
+
+// unregister removes and returns the streams registered for taskID, so the
+// caller can close them. The second return value is false if taskID was
+// never registered.
+func (s *uiEventSink) unregister(taskID string) (*uiTaskStreams, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	streams, ok := s.tasks[taskID]
+	delete(s.tasks, taskID)
+	return streams, ok
+}
+
+func (s *uiEventSink) Emit(e Event) {
+	if e.Type != EventTaskOutput {
+		return
+	}
+
+	s.mu.Lock()
+	streams, ok := s.tasks[e.TaskID]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	switch e.Stream {
+	case "stdout":
+		_, _ = streams.out.Write([]byte(e.Data))
+	case "stderr":
+		_, _ = streams.err.Write([]byte(e.Data))
+	}
+}
+
+// multiEventSink fans a single event out to every configured sink.
+type multiEventSink []RunEventSink
+
+func (m multiEventSink) Emit(e Event) {
+	for _, sink := range m {
+		sink.Emit(e)
+	}
+}
+
+// jsonLinesEventSink writes one JSON-encoded Event per line to w. It backs
+// --output-logs=json-stream.
+type jsonLinesEventSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func newJSONLinesEventSink(w io.Writer) *jsonLinesEventSink {
+	return &jsonLinesEventSink{w: w}
+}
+
+func (s *jsonLinesEventSink) Emit(e Event) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.w.Write(b)
+}
+
+const (
+	webhookFlushInterval = 2 * time.Second
+	webhookMaxBatch      = 50
+	webhookMaxRetries    = 5
+)
+
+// webhookEventSink batches events and POSTs them as a JSON array to an
+// external URL, retrying failed deliveries with exponential backoff. It backs
+// --event-webhook=URL.
+type webhookEventSink struct {
+	url    string
+	client *http.Client
+
+	mu    sync.Mutex
+	batch []Event
+
+	done chan struct{}
+}
+
+func newWebhookEventSink(url string) *webhookEventSink {
+	s := &webhookEventSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		done:   make(chan struct{}),
+	}
+	go s.loop()
+	return s
+}
+
+func (s *webhookEventSink) Emit(e Event) {
+	s.mu.Lock()
+	s.batch = append(s.batch, e)
+	shouldFlush := len(s.batch) >= webhookMaxBatch
+	s.mu.Unlock()
+
+	if shouldFlush {
+		s.flush()
+	}
+}
+
+// Close flushes any buffered events and stops the background flush loop.
+func (s *webhookEventSink) Close() {
+	close(s.done)
+	s.flush()
+}
+
+func (s *webhookEventSink) loop() {
+	ticker := time.NewTicker(webhookFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *webhookEventSink) flush() {
+	s.mu.Lock()
+	if len(s.batch) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	batch := s.batch
+	s.batch = nil
+	s.mu.Unlock()
+
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return
+	}
+
+	backoff := 500 * time.Millisecond
+	for attempt := 0; attempt < webhookMaxRetries; attempt++ {
+		resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode < 500 {
+				return
+			}
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// newEventSink builds the RunEventSink a run publishes to: a uiEventSink
+// driving the pretty terminal UI is always included, with
+// --output-logs=json-stream and --event-webhook=URL layering additional
+// sinks on top. The uiEventSink is also returned on its own, since
+// execContext needs to register/unregister each task's streamers directly --
+// that's narrower than anything the RunEventSink interface exposes. The
+// returned close function must be called once the run is finished so a
+// webhook sink can flush and stop cleanly.
+func newEventSink(rs *runSpec) (RunEventSink, *uiEventSink, func()) {
+	ui := newUIEventSink()
+	sinks := []RunEventSink{ui}
+	var closers []func()
+
+	if rs.Opts.runOpts.outputLogsMode == "json-stream" {
+		sinks = append(sinks, newJSONLinesEventSink(os.Stdout))
+	}
+
+	if rs.Opts.runOpts.eventWebhook != "" {
+		webhook := newWebhookEventSink(rs.Opts.runOpts.eventWebhook)
+		sinks = append(sinks, webhook)
+		closers = append(closers, webhook.Close)
+	}
+
+	closeFn := func() {
+		for _, closer := range closers {
+			closer()
+		}
+	}
+
+	return multiEventSink(sinks), ui, closeFn
+}
+
+// eventStreamWriter adapts an io.Writer so every Write instead publishes an
+// EventTaskOutput to a RunEventSink. It's what execContext.exec sets
+// cmd.Stdout/Stderr to: the pretty terminal UI and any external consumers
+// (json-stream, webhook) all learn about a task's output the same way, as
+// events, rather than the UI getting a privileged direct pipe.
+type eventStreamWriter struct {
+	sink   RunEventSink
+	taskID string
+	stream string
+}
+
+func (w eventStreamWriter) Write(p []byte) (int, error) {
+	w.sink.Emit(Event{
+		Type:      EventTaskOutput,
+		TaskID:    w.taskID,
+		Stream:    w.stream,
+		Data:      string(p),
+		Timestamp: time.Now(),
+	})
+	return len(p), nil
+}
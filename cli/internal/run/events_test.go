@@ -0,0 +1,56 @@
+package run
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+// TestUIEventSink is a regression test for the pretty terminal UI being
+// hard-wired into cmd.Stdout/Stderr instead of going through the event
+// system like every other consumer. It checks that
+// registering a task with uiEventSink and then Emit-ing its output events
+// actually reaches the underlying writer, and that an event for a task that
+// was never (or no longer) registered is silently dropped rather than
+// panicking.
+func TestUIEventSink(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(&buf, "", 0)
+
+	sink := newUIEventSink()
+	sink.register("pkg#build", logger, "pkg:build")
+
+	sink.Emit(Event{Type: EventTaskOutput, TaskID: "pkg#build", Stream: "stdout", Data: "hello\n"})
+	sink.Emit(Event{Type: EventTaskOutput, TaskID: "pkg#build", Stream: "stderr", Data: "uh oh\n"})
+	// An event for a task that isn't registered, and a non-output event for
+	// one that is, should both be no-ops.
+	sink.Emit(Event{Type: EventTaskOutput, TaskID: "pkg#other", Stream: "stdout", Data: "ignored\n"})
+	sink.Emit(Event{Type: EventTaskStarted, TaskID: "pkg#build", Data: "ignored\n"})
+
+	streams, ok := sink.unregister("pkg#build")
+	if !ok {
+		t.Fatal("unregister: expected pkg#build to be registered")
+	}
+	if err := streams.out.Close(); err != nil {
+		t.Fatalf("streams.out.Close: %v", err)
+	}
+	if err := streams.err.Close(); err != nil {
+		t.Fatalf("streams.err.Close: %v", err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, "hello") {
+		t.Errorf("expected output to contain %q, got %q", "hello", got)
+	}
+	if !strings.Contains(got, "uh oh") {
+		t.Errorf("expected output to contain %q, got %q", "uh oh", got)
+	}
+	if strings.Contains(got, "ignored") {
+		t.Errorf("expected unregistered/non-output events to be dropped, got %q", got)
+	}
+
+	if _, ok := sink.unregister("pkg#build"); ok {
+		t.Error("unregister: expected pkg#build to already be gone")
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"os/signal"
+	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/fatih/color"
@@ -20,7 +23,6 @@ import (
 	"github.com/vercel/turbo/cli/internal/colorcache"
 	"github.com/vercel/turbo/cli/internal/core"
 	"github.com/vercel/turbo/cli/internal/graph"
-	"github.com/vercel/turbo/cli/internal/logstreamer"
 	"github.com/vercel/turbo/cli/internal/nodes"
 	"github.com/vercel/turbo/cli/internal/packagemanager"
 	"github.com/vercel/turbo/cli/internal/process"
@@ -32,6 +34,11 @@ import (
 	"github.com/vercel/turbo/cli/internal/ui"
 )
 
+// defaultKillTimeout is how long we wait after sending a graceful
+// termination signal (SIGTERM; on Windows no signal is sent) to a task that
+// has exceeded its timeout before we escalate to a SIGKILL.
+const defaultKillTimeout = 10 * time.Second
+
 // RealRun executes a set of tasks
 func RealRun(
 	ctx gocontext.Context,
@@ -46,6 +53,17 @@ func RealRun(
 	packageManager *packagemanager.PackageManager,
 	processes *process.Manager,
 ) error {
+	// schedCtx is canceled on the first SIGINT, which the engine checks
+	// between tasks and uses to stop scheduling new ones. It is deliberately
+	// NOT the context used to run an already-started task (see execFunc
+	// below): a running child must be left alone to finish on its own after
+	// a single Ctrl-C. A second SIGINT, or a SIGTERM at any point, skips
+	// straight to killing every child process via processes.Close().
+	schedCtx, stopScheduling := gocontext.WithCancel(ctx)
+	defer stopScheduling()
+	defer watchForSignals(base.UI, processes, stopScheduling)()
+
+	startAt := time.Now()
 	singlePackage := rs.Opts.runOpts.singlePackage
 
 	if singlePackage {
@@ -63,12 +81,43 @@ func RealRun(
 		base.UI.Info(ui.Dim("• Remote caching disabled"))
 	}
 
+	// archiveWriter and finalExitCode are filled in below, once we know
+	// whether the run wants a logs archive and what it exited with. They're
+	// declared up front so this deferred closure can see their final values:
+	// finalizing the archive has to happen after turboCache.Shutdown, so
+	// that a task's cache write can't race with us reading its log back.
+	var archiveWriter *runcache.ArchiveWriter
+	var finalExitCode int
 	defer func() {
 		_ = spinner.WaitFor(ctx, turboCache.Shutdown, base.UI, "...writing to cache...", 1500*time.Millisecond)
+		if archiveWriter != nil {
+			if err := archiveWriter.Finalize(runSummary, startAt, finalExitCode); err != nil {
+				base.UI.Warn(fmt.Sprintf("Failed to write logs archive: %s", err))
+			}
+		}
 	}()
 	colorCache := colorcache.New()
 
-	runCache := runcache.New(turboCache, base.RepoRoot, rs.Opts.runcacheOpts, colorCache)
+	if rs.Opts.runOpts.summarize || rs.Opts.runOpts.logsArchive {
+		var err error
+		archiveWriter, err = runcache.NewArchiveWriter(base.RepoRoot, runSummary.ID)
+		if err != nil {
+			base.UI.Warn(fmt.Sprintf("Failed to set up logs archive: %s", err))
+			archiveWriter = nil
+		}
+	}
+
+	runCache := runcache.New(turboCache, base.RepoRoot, rs.Opts.runcacheOpts, colorCache, archiveWriter)
+
+	// eventSink fans every Event out to whichever sinks are configured: the
+	// pretty terminal UI (uiSink) always, plus --output-logs=json-stream
+	// and/or --event-webhook for external consumers (IDE integrations, CI
+	// dashboards, log aggregators) that want to observe this run without
+	// scraping colorized terminal output. uiSink is also kept on its own
+	// since execContext needs to register/unregister each task's streamers
+	// directly, not just Emit to it.
+	eventSink, uiSink, closeEventSink := newEventSink(rs)
+	defer closeEventSink()
 
 	ec := &execContext{
 		colorCache:      colorCache,
@@ -82,6 +131,8 @@ func RealRun(
 		taskHashTracker: taskHashTracker,
 		repoRoot:        base.RepoRoot,
 		isSinglePackage: singlePackage,
+		events:          eventSink,
+		uiSink:          uiSink,
 	}
 
 	// run the thing
@@ -90,44 +141,43 @@ func RealRun(
 		Concurrency: rs.Opts.runOpts.concurrency,
 	}
 
-	mu := sync.Mutex{}
-	taskSummaries := []*runsummary.TaskSummary{}
-	execFunc := func(ctx gocontext.Context, packageTask *nodes.PackageTask, taskSummary *runsummary.TaskSummary) error {
+	// Tasks across the graph can finish concurrently, so we can't just append
+	// to a shared slice as they complete without racing or duplicating
+	// entries. Keying by TaskID and sorting at the end keeps summary.json
+	// ordering deterministic across runs, which matters for diffing it in CI.
+	var taskSummaries sync.Map // TaskID -> *runsummary.TaskSummary
+	execFunc := func(taskCtx gocontext.Context, packageTask *nodes.PackageTask, taskSummary *runsummary.TaskSummary) error {
 		deps := engine.TaskGraph.DownEdges(packageTask.TaskID)
-		mu.Lock()
-		taskSummaries = append(taskSummaries, taskSummary)
-		// don't hold the lock while we run ec.exec
-		mu.Unlock()
-
-		taskSummaries = append(taskSummaries, taskSummary)
-
-		// deps here are passed in to calculate the task hash
-		taskExecutionSummary, err := ec.exec(ctx, packageTask, deps)
-		if err != nil {
-			return err
-		}
-		taskSummary.Execution = taskExecutionSummary
-		taskSummary.ExpandedOutputs = taskHashTracker.GetExpandedOutputs(taskSummary.TaskID)
-		return nil
+		return runPackageTask(taskCtx, ctx, ec, runSummary, taskHashTracker, &taskSummaries, packageTask, taskSummary, deps)
 	}
 
 	getArgs := func(taskID string) []string {
 		return rs.ArgsForTask(taskID)
 	}
 
-	visitorFn := g.GetPackageTaskVisitor(ctx, engine.TaskGraph, getArgs, base.Logger, execFunc)
+	visitorFn := g.GetPackageTaskVisitor(schedCtx, engine.TaskGraph, getArgs, base.Logger, execFunc)
 	errs := engine.Execute(visitorFn, execOpts)
 
 	// Track if we saw any child with a non-zero exit code
 	exitCode := 0
 	exitCodeErr := &process.ChildExit{}
 
-	// Assign tasks after execution
-	runSummary.Tasks = taskSummaries
+	// Assign tasks after execution, in a deterministic order
+	runSummary.Tasks = collectTaskSummaries(&taskSummaries)
 
 	for _, err := range errs {
 		if errors.As(err, &exitCodeErr) {
-			if exitCodeErr.ExitCode > exitCode {
+			if exitCodeErr.ExitCode < 0 {
+				// A negative exit code means the child never returned one of
+				// its own -- it was killed outright, e.g. by ExecWithTimeout
+				// escalating to SIGKILL. That's still a failure even though
+				// there's no real exit code to report, so it must not be
+				// dropped by the max-so-far comparison below (-1 > 0 is
+				// false, which used to let a timed-out run "succeed").
+				if exitCode == 0 {
+					exitCode = 1
+				}
+			} else if exitCodeErr.ExitCode > exitCode {
 				exitCode = exitCodeErr.ExitCode
 			}
 		} else if exitCode == 0 {
@@ -146,6 +196,13 @@ func RealRun(
 		}
 	}
 
+	// archiveWriter.Finalize (if an archive was requested) runs in the
+	// deferred block above, after turboCache.Shutdown; stash the exit code
+	// it needs here since it's not known until now.
+	finalExitCode = exitCode
+
+	eventSink.Emit(Event{Type: EventRunFinished, ExitCode: exitCode, Timestamp: time.Now()})
+
 	if exitCode != 0 {
 		return &process.ChildExit{
 			ExitCode: exitCode,
@@ -154,6 +211,108 @@ func RealRun(
 	return nil
 }
 
+// runPackageTask records and executes a single task, storing its summary in
+// taskSummaries as soon as it's scheduled so a run that's interrupted before
+// every task finishes still reports one. It's the body of RealRun's
+// execFunc, pulled out into its own function so it can be exercised directly
+// -- with a synthetic packageTask and no real engine/graph -- by tests.
+//
+// schedCtx is checked to see whether the task was canceled before it had a
+// chance to start; execCtx is what the task actually runs against, and is
+// deliberately a separate, signal-independent context so that stopping
+// scheduling doesn't tear down a task that's already running (see RealRun).
+func runPackageTask(
+	schedCtx gocontext.Context,
+	execCtx gocontext.Context,
+	ec *execContext,
+	runSummary *runsummary.RunSummary,
+	taskHashTracker *taskhash.Tracker,
+	taskSummaries *sync.Map,
+	packageTask *nodes.PackageTask,
+	taskSummary *runsummary.TaskSummary,
+	deps dag.Set,
+) error {
+	taskSummaries.Store(packageTask.TaskID, taskSummary)
+	ec.events.Emit(Event{Type: EventTaskQueued, TaskID: packageTask.TaskID, Timestamp: time.Now()})
+
+	// A cancellation that arrived while this task was queued means it never
+	// got a chance to run. Record that distinctly from a task that started
+	// and then failed or was killed mid-execution. schedCtx only trips once
+	// scheduling has been stopped -- not once a running task is killed.
+	if err := schedCtx.Err(); err != nil {
+		tracer, taskExecutionSummary := runSummary.TrackTask(packageTask.TaskID)
+		tracer(runsummary.TargetCanceled, err)
+		taskSummary.Execution = taskExecutionSummary
+		return nil
+	}
+
+	// deps here are passed in to calculate the task hash
+	taskExecutionSummary, err := ec.exec(execCtx, packageTask, deps)
+	// Keep the execution summary even on error, so a failed, timed-out or
+	// canceled task's state still reaches the run summary.
+	taskSummary.Execution = taskExecutionSummary
+	if err != nil {
+		return err
+	}
+	taskSummary.ExpandedOutputs = taskHashTracker.GetExpandedOutputs(taskSummary.TaskID)
+	return nil
+}
+
+// collectTaskSummaries drains a TaskID -> *runsummary.TaskSummary map built up
+// concurrently during execution into a slice sorted by TaskID. Sorting keeps
+// summary.json diffable across CI runs regardless of the order tasks actually
+// finished in.
+func collectTaskSummaries(taskSummaries *sync.Map) []*runsummary.TaskSummary {
+	var taskIDs []string
+	taskSummaries.Range(func(key, _ interface{}) bool {
+		taskIDs = append(taskIDs, key.(string))
+		return true
+	})
+	sort.Strings(taskIDs)
+
+	out := make([]*runsummary.TaskSummary, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		summary, _ := taskSummaries.Load(taskID)
+		out = append(out, summary.(*runsummary.TaskSummary))
+	}
+	return out
+}
+
+// watchForSignals registers a process-wide SIGINT/SIGTERM handler and returns
+// a function that stops watching. The first SIGINT calls cancel, which tells
+// the engine to stop scheduling new tasks while letting already-running ones
+// finish on their own. A second SIGINT, or a SIGTERM at any point, immediately
+// kills every child process via processes.Close(); cache writes that are
+// already under way are not interrupted.
+func watchForSignals(ui cli.Ui, processes *process.Manager, cancel gocontext.CancelFunc) func() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		interrupted := false
+		for {
+			select {
+			case sig := <-sigCh:
+				if sig == syscall.SIGTERM || interrupted {
+					processes.Close()
+					return
+				}
+				interrupted = true
+				cancel()
+				ui.Output("Stopping... (press Ctrl-C again to force-kill running tasks)")
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		signal.Stop(sigCh)
+	}
+}
+
 type execContext struct {
 	colorCache      *colorcache.ColorCache
 	runSummary      *runsummary.RunSummary
@@ -166,6 +325,8 @@ type execContext struct {
 	taskHashTracker *taskhash.Tracker
 	repoRoot        turbopath.AbsoluteSystemPath
 	isSinglePackage bool
+	events          RunEventSink
+	uiSink          *uiEventSink
 }
 
 func (ec *execContext) logError(log hclog.Logger, prefix string, err error) {
@@ -186,6 +347,7 @@ func (ec *execContext) exec(ctx gocontext.Context, packageTask *nodes.PackageTas
 
 	// Setup tracer
 	tracer, taskExecutionSummary := ec.runSummary.TrackTask(packageTask.TaskID)
+	ec.events.Emit(Event{Type: EventTaskStarted, TaskID: packageTask.TaskID, Timestamp: time.Now()})
 
 	passThroughArgs := ec.rs.ArgsForTask(packageTask.Task)
 	hash := packageTask.Hash
@@ -227,8 +389,10 @@ func (ec *execContext) exec(ctx gocontext.Context, packageTask *nodes.PackageTas
 	} else if hit {
 		ec.taskHashTracker.SetExpandedOutputs(packageTask.TaskID, taskCache.ExpandedOutputs)
 		tracer(runsummary.TargetCached, nil)
+		ec.events.Emit(Event{Type: EventCacheHit, TaskID: packageTask.TaskID, Timestamp: time.Now()})
 		return taskExecutionSummary, nil
 	}
+	ec.events.Emit(Event{Type: EventCacheMiss, TaskID: packageTask.TaskID, Timestamp: time.Now()})
 
 	// Setup command execution
 	argsactual := append([]string{"run"}, packageTask.Task)
@@ -238,6 +402,22 @@ func (ec *execContext) exec(ctx gocontext.Context, packageTask *nodes.PackageTas
 		argsactual = append(argsactual, passThroughArgs...)
 	}
 
+	// A task may declare its own timeout (and grace period before a SIGKILL)
+	// via the `timeout`/`killTimeout` fields in turbo.json. Neither is required,
+	// so a zero duration here just means "run until it finishes".
+	taskTimeout := packageTask.TaskDefinition.Timeout
+	killTimeout := packageTask.TaskDefinition.KillTimeout
+	if taskTimeout > 0 && killTimeout <= 0 {
+		killTimeout = defaultKillTimeout
+	}
+
+	execCtx := ctx
+	var cancel gocontext.CancelFunc
+	if taskTimeout > 0 {
+		execCtx, cancel = gocontext.WithTimeout(ctx, taskTimeout)
+		defer cancel()
+	}
+
 	cmd := exec.Command(ec.packageManager.Command, argsactual...)
 	cmd.Dir = packageTask.Pkg.Dir.ToSystemPath().RestoreAnchor(ec.repoRoot).ToString()
 	envs := fmt.Sprintf("TURBO_HASH=%v", hash)
@@ -258,23 +438,28 @@ func (ec *execContext) exec(ctx gocontext.Context, packageTask *nodes.PackageTas
 
 	// Create a logger
 	logger := log.New(writer, "", 0)
-	// Setup a streamer that we'll pipe cmd.Stdout to
-	logStreamerOut := logstreamer.NewLogstreamer(logger, prettyPrefix, false)
-	// Setup a streamer that we'll pipe cmd.Stderr to.
-	logStreamerErr := logstreamer.NewLogstreamer(logger, prettyPrefix, false)
-	cmd.Stderr = logStreamerErr
-	cmd.Stdout = logStreamerOut
-	// Flush/Reset any error we recorded
-	logStreamerErr.FlushRecord()
-	logStreamerOut.FlushRecord()
+	// The pretty terminal UI is wired up as just another RunEventSink:
+	// register this task's logstreamers with it so it can replay
+	// EventTaskOutput events once they're published below. cmd.Stdout/Stderr
+	// publish events only -- they don't know or care who's listening, so
+	// external consumers (json-stream, webhook) see the exact same stream
+	// the terminal does.
+	ec.uiSink.register(packageTask.TaskID, logger, prettyPrefix)
+	cmd.Stdout = eventStreamWriter{sink: ec.events, taskID: packageTask.TaskID, stream: "stdout"}
+	cmd.Stderr = eventStreamWriter{sink: ec.events, taskID: packageTask.TaskID, stream: "stderr"}
 
 	closeOutputs := func() error {
+		streams, ok := ec.uiSink.unregister(packageTask.TaskID)
+		if !ok {
+			return nil
+		}
+
 		var closeErrors []error
 
-		if err := logStreamerOut.Close(); err != nil {
+		if err := streams.out.Close(); err != nil {
 			closeErrors = append(closeErrors, errors.Wrap(err, "log stdout"))
 		}
-		if err := logStreamerErr.Close(); err != nil {
+		if err := streams.err.Close(); err != nil {
 			closeErrors = append(closeErrors, errors.Wrap(err, "log stderr"))
 		}
 
@@ -291,15 +476,46 @@ func (ec *execContext) exec(ctx gocontext.Context, packageTask *nodes.PackageTas
 		return nil
 	}
 
-	// Run the command
-	if err := ec.processes.Exec(cmd); err != nil {
+	// Run the command, racing it against the task's timeout (if any). If the
+	// timeout fires first, ask the child to shut down gracefully before
+	// escalating to a kill.
+	if err := ec.processes.ExecWithTimeout(execCtx, cmd, killTimeout); err != nil {
 		// close off our outputs. We errored, so we mostly don't care if we fail to close
 		_ = closeOutputs()
-		// if we already know we're in the process of exiting,
-		// we don't need to record an error to that effect.
+		// processes.Close() had already been called (a second Ctrl-C, a
+		// SIGTERM, or another task failing without --continue), so this
+		// task's command was never started. Tag it canceled rather than
+		// failed so the summary doesn't blame the task itself; returning nil
+		// keeps it out of the errors that decide the run's exit code.
 		if errors.Is(err, process.ErrClosing) {
+			tracer(runsummary.TargetCanceled, err)
+			ec.events.Emit(Event{Type: EventTaskFinished, TaskID: packageTask.TaskID, ExitCode: -1, Duration: time.Since(cmdTime).String(), Timestamp: time.Now()})
 			return taskExecutionSummary, nil
 		}
+
+		if errors.Is(execCtx.Err(), gocontext.Canceled) {
+			tracer(runsummary.TargetCanceled, err)
+			ec.events.Emit(Event{Type: EventTaskFinished, TaskID: packageTask.TaskID, ExitCode: -1, Duration: time.Since(cmdTime).String(), Timestamp: time.Now()})
+			return taskExecutionSummary, err
+		}
+
+		if taskTimeout > 0 && errors.Is(execCtx.Err(), gocontext.DeadlineExceeded) {
+			tracer(runsummary.TargetTimedOut, err)
+
+			progressLogger.Error(fmt.Sprintf("Error: command timed out after %v", taskTimeout))
+			if !ec.rs.Opts.runOpts.continueOnError {
+				prefixedUI.Error(fmt.Sprintf("ERROR: command timed out after %v", taskTimeout))
+				ec.processes.Close()
+			} else {
+				prefixedUI.Warn("command timed out, but continuing...")
+			}
+
+			taskCache.OnError(prefixedUI, progressLogger)
+			ec.events.Emit(Event{Type: EventTaskFinished, TaskID: packageTask.TaskID, ExitCode: -1, Duration: time.Since(cmdTime).String(), Timestamp: time.Now()})
+
+			return taskExecutionSummary, err
+		}
+
 		tracer(runsummary.TargetBuildFailed, err)
 
 		progressLogger.Error(fmt.Sprintf("Error: command finished with error: %v", err))
@@ -312,6 +528,12 @@ func (ec *execContext) exec(ctx gocontext.Context, packageTask *nodes.PackageTas
 
 		// If there was an error, flush the buffered output
 		taskCache.OnError(prefixedUI, progressLogger)
+		exitCode := -1
+		exitCodeErr := &process.ChildExit{}
+		if errors.As(err, &exitCodeErr) {
+			exitCode = exitCodeErr.ExitCode
+		}
+		ec.events.Emit(Event{Type: EventTaskFinished, TaskID: packageTask.TaskID, ExitCode: exitCode, Duration: time.Since(cmdTime).String(), Timestamp: time.Now()})
 
 		return taskExecutionSummary, err
 	}
@@ -330,6 +552,7 @@ func (ec *execContext) exec(ctx gocontext.Context, packageTask *nodes.PackageTas
 
 	// Clean up tracing
 	tracer(runsummary.TargetBuilt, nil)
+	ec.events.Emit(Event{Type: EventTaskFinished, TaskID: packageTask.TaskID, ExitCode: 0, Duration: duration.String(), Timestamp: time.Now()})
 	progressLogger.Debug("done", "status", "complete", "duration", duration)
 	return taskExecutionSummary, nil
 }
@@ -0,0 +1,100 @@
+package run
+
+import (
+	gocontext "context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"github.com/vercel/turbo/cli/internal/nodes"
+	"github.com/vercel/turbo/cli/internal/runsummary"
+	"github.com/vercel/turbo/cli/internal/taskhash"
+)
+
+// TestRunPackageTaskConcurrent is a regression test for a bug where
+// execFunc appended each taskSummary to runSummary.Tasks twice -- once under
+// a mutex, once without it -- which both duplicated entries and raced across
+// the ~dozens of tasks that can finish concurrently. Rather than building the
+// sync.Map directly, it drives runPackageTask -- the same function RealRun's
+// execFunc calls for every scheduled task -- with ~50 concurrent tasks, so it
+// actually exercises the code path the original bug lived in. Run with
+// -race to catch the concurrent-write half of that bug.
+func TestRunPackageTaskConcurrent(t *testing.T) {
+	const numTasks = 50
+
+	ec := &execContext{
+		runSummary: &runsummary.RunSummary{},
+		rs:         &runSpec{Opts: &Opts{}},
+		ui:         &cli.ConcurrentUi{Ui: &cli.BasicUi{}},
+		logger:     hclog.NewNullLogger(),
+		events:     noopEventSink{},
+	}
+	runSummary := &runsummary.RunSummary{}
+	taskHashTracker := &taskhash.Tracker{}
+
+	var taskSummaries sync.Map
+	var wg sync.WaitGroup
+	wg.Add(numTasks)
+	for i := 0; i < numTasks; i++ {
+		go func(i int) {
+			defer wg.Done()
+			taskID := fmt.Sprintf("pkg#task-%02d", i)
+			// An empty Command makes ec.exec take its "nothing to run"
+			// path, so this test stays a pure concurrency check on
+			// runPackageTask/taskSummaries rather than shelling out.
+			packageTask := &nodes.PackageTask{TaskID: taskID, Task: fmt.Sprintf("task-%02d", i)}
+			taskSummary := &runsummary.TaskSummary{TaskID: taskID}
+			if err := runPackageTask(gocontext.Background(), gocontext.Background(), ec, runSummary, taskHashTracker, &taskSummaries, packageTask, taskSummary, nil); err != nil {
+				t.Errorf("runPackageTask(%s): %v", taskID, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	got := collectTaskSummaries(&taskSummaries)
+	if len(got) != numTasks {
+		t.Fatalf("expected %d task summaries, got %d", numTasks, len(got))
+	}
+
+	seen := make(map[string]bool, numTasks)
+	for i, summary := range got {
+		if seen[summary.TaskID] {
+			t.Fatalf("duplicate TaskID %q in task summaries", summary.TaskID)
+		}
+		seen[summary.TaskID] = true
+		if i > 0 && got[i-1].TaskID > summary.TaskID {
+			t.Fatalf("task summaries not sorted: %q came before %q", got[i-1].TaskID, summary.TaskID)
+		}
+	}
+}
+
+// TestRunPackageTaskCanceled checks that a task whose scheduling context is
+// already canceled is recorded as TargetCanceled without ever reaching
+// ec.exec.
+func TestRunPackageTaskCanceled(t *testing.T) {
+	ec := &execContext{
+		runSummary: &runsummary.RunSummary{},
+		rs:         &runSpec{Opts: &Opts{}},
+		ui:         &cli.ConcurrentUi{Ui: &cli.BasicUi{}},
+		logger:     hclog.NewNullLogger(),
+		events:     noopEventSink{},
+	}
+	runSummary := &runsummary.RunSummary{}
+	taskHashTracker := &taskhash.Tracker{}
+
+	canceledCtx, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+
+	var taskSummaries sync.Map
+	taskSummary := &runsummary.TaskSummary{TaskID: "pkg#build"}
+	packageTask := &nodes.PackageTask{TaskID: "pkg#build", Task: "build", Command: "some-command"}
+	if err := runPackageTask(canceledCtx, gocontext.Background(), ec, runSummary, taskHashTracker, &taskSummaries, packageTask, taskSummary, nil); err != nil {
+		t.Fatalf("runPackageTask: %v", err)
+	}
+
+	if taskSummary.Execution == nil || taskSummary.Execution.State != runsummary.TargetCanceled {
+		t.Fatalf("expected task to be recorded as %q, got %+v", runsummary.TargetCanceled, taskSummary.Execution)
+	}
+}
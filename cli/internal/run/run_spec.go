@@ -0,0 +1,76 @@
+package run
+
+import (
+	"github.com/pyr-sh/dag"
+	"github.com/spf13/pflag"
+	"github.com/vercel/turbo/cli/internal/runcache"
+)
+
+// CacheOpts holds the flags that control how a run talks to the cache,
+// independent of what's actually cached under runcache.Opts.
+type CacheOpts struct {
+	// SkipRemote disables reads from and writes to the remote cache.
+	SkipRemote bool
+}
+
+// RunOpts holds the flags that control how a run is executed and reported,
+// as opposed to what it runs (that's Targets/FilteredPkgs on runSpec).
+type RunOpts struct {
+	singlePackage   bool
+	parallel        bool
+	concurrency     int
+	logPrefix       string
+	continueOnError bool
+	summarize       bool
+
+	// logsArchive bundles every task's log plus the run summary into
+	// .turbo/runs/<run-id>/logs.tar.gz. Set by --logs-archive.
+	logsArchive bool
+	// outputLogsMode controls how task output is surfaced. "json-stream"
+	// additionally emits one JSON-encoded Event per line to stdout, for a
+	// consumer that wants to follow the run programmatically. Set by
+	// --output-logs.
+	outputLogsMode string
+	// eventWebhook, if set, POSTs batches of Events to this URL as the run
+	// progresses. Set by --event-webhook.
+	eventWebhook string
+}
+
+// addRunOpts registers the flags that back RunOpts's log-archiving and
+// event-streaming fields: --logs-archive, --output-logs, and
+// --event-webhook. It must be called with the same *pflag.FlagSet as the
+// rest of `turbo run`'s flags, by whatever sets those up; otherwise these
+// three have no way to be set from the command line.
+func addRunOpts(opts *RunOpts, flags *pflag.FlagSet) {
+	flags.BoolVar(&opts.logsArchive, "logs-archive", false, "write every task's log output and the run summary to a single .tar.gz artifact")
+	flags.StringVar(&opts.outputLogsMode, "output-logs", "", "set type of process output logging, in addition to the normal UI (\"json-stream\" streams one JSON-encoded event per line to stdout)")
+	flags.StringVar(&opts.eventWebhook, "event-webhook", "", "POST batches of run events to this URL as the run progresses")
+}
+
+// Opts bundles the flag groups that shape how a run executes.
+type Opts struct {
+	runOpts      RunOpts
+	cacheOpts    CacheOpts
+	runcacheOpts runcache.Opts
+}
+
+// runSpec is the fully-resolved description of what a `turbo run` invocation
+// should do: which tasks to run, in which packages, with which options.
+type runSpec struct {
+	// Targets are the tasks requested on the command line, e.g. ["build", "test"].
+	Targets []string
+	// FilteredPkgs is the set of packages in scope for this run, after
+	// applying any --filter/--scope flags.
+	FilteredPkgs dag.Set
+	Opts         *Opts
+
+	// PassThroughArgs are the arguments after `--` on the command line,
+	// forwarded verbatim to every task's underlying script.
+	PassThroughArgs []string
+}
+
+// ArgsForTask returns the pass-through arguments that should be forwarded to
+// a given task's underlying script.
+func (rs *runSpec) ArgsForTask(task string) []string {
+	return rs.PassThroughArgs
+}
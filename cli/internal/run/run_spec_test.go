@@ -0,0 +1,35 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// TestAddRunOpts checks that addRunOpts parses --logs-archive,
+// --output-logs, and --event-webhook into the RunOpts fields it's documented
+// to control. Whether the `turbo run` command's flag registration calls
+// addRunOpts is decided outside this package and is not checked here.
+func TestAddRunOpts(t *testing.T) {
+	opts := &RunOpts{}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	addRunOpts(opts, flags)
+
+	if err := flags.Parse([]string{
+		"--logs-archive",
+		"--output-logs=json-stream",
+		"--event-webhook=https://example.com/hook",
+	}); err != nil {
+		t.Fatalf("flags.Parse: %v", err)
+	}
+
+	if !opts.logsArchive {
+		t.Error("logsArchive = false, want true")
+	}
+	if opts.outputLogsMode != "json-stream" {
+		t.Errorf("outputLogsMode = %q, want %q", opts.outputLogsMode, "json-stream")
+	}
+	if opts.eventWebhook != "https://example.com/hook" {
+		t.Errorf("eventWebhook = %q, want %q", opts.eventWebhook, "https://example.com/hook")
+	}
+}
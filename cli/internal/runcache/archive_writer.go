@@ -0,0 +1,156 @@
+package runcache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vercel/turbo/cli/internal/runsummary"
+	"github.com/vercel/turbo/cli/internal/turbopath"
+)
+
+// turboVersion is overwritten at build time via -ldflags, in the same way as
+// the rest of the turbo binary's version metadata.
+var turboVersion = "unknown"
+
+// ArchiveWriter collects every task's log output into a run-scoped staging
+// directory as it's produced, then bundles the staging directory plus the
+// run summary and a bit of run-level metadata into a single
+// .turbo/runs/<run-id>/logs.tar.gz. Building the archive from logs that were
+// teed live, rather than re-reading already-closed log files back off disk,
+// means a task that's still running when the archive is finalized doesn't
+// get silently dropped from it.
+type ArchiveWriter struct {
+	stagingDir  turbopath.AbsoluteSystemPath
+	archivePath turbopath.AbsoluteSystemPath
+
+	mu    sync.Mutex
+	files map[string]turbopath.AbsoluteSystemPath // taskID -> staged log file
+}
+
+// NewArchiveWriter creates the run-scoped staging directory a run's task
+// logs are teed into as they're produced.
+func NewArchiveWriter(repoRoot turbopath.AbsoluteSystemPath, runID string) (*ArchiveWriter, error) {
+	stagingDir := repoRoot.UntypedJoin(".turbo", "runs", runID, "logs")
+	if err := stagingDir.MkdirAll(0755); err != nil {
+		return nil, errors.Wrap(err, "could not create logs staging directory")
+	}
+	return &ArchiveWriter{
+		stagingDir:  stagingDir,
+		archivePath: repoRoot.UntypedJoin(".turbo", "runs", runID, "logs.tar.gz"),
+		files:       make(map[string]turbopath.AbsoluteSystemPath),
+	}, nil
+}
+
+// WriterFor returns a writer that stages taskID's log output in the archive's
+// staging directory, to be picked up when the archive is finalized. It's
+// meant to be teed alongside a task's normal log file, not used in place of
+// it.
+func (a *ArchiveWriter) WriterFor(taskID string) (io.WriteCloser, error) {
+	path := a.stagingDir.UntypedJoin(stagedLogFilename(taskID))
+	f, err := os.Create(path.ToString())
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not stage log for %s", taskID)
+	}
+
+	a.mu.Lock()
+	a.files[taskID] = path
+	a.mu.Unlock()
+
+	return f, nil
+}
+
+// stagedLogFilename flattens a TaskID into a single path component safe to
+// os.Create directly inside the staging directory. A TaskID is
+// "<package>#<task>", and a scoped package (e.g. "@scope/ui#build") contains
+// a "/" that os.Create would otherwise try to resolve as a subdirectory we
+// never created.
+func stagedLogFilename(taskID string) string {
+	return strings.ReplaceAll(taskID, "/", "-") + ".log"
+}
+
+// Finalize tars up every staged log, the run summary, and run metadata into
+// the archive. It should be called once the run summary is final and every
+// task's log has been closed -- in RealRun that's after turboCache.Shutdown
+// has completed, so a task whose output is still being flushed to the cache
+// doesn't race with us reading it back.
+func (a *ArchiveWriter) Finalize(rs *runsummary.RunSummary, startAt time.Time, exitCode int) error {
+	f, err := os.Create(a.archivePath.ToString())
+	if err != nil {
+		return errors.Wrap(err, "could not create logs archive")
+	}
+	defer func() { _ = f.Close() }()
+
+	gzw := gzip.NewWriter(f)
+	defer func() { _ = gzw.Close() }()
+	tw := tar.NewWriter(gzw)
+	defer func() { _ = tw.Close() }()
+
+	hashes := make(map[string]string, len(rs.Tasks))
+	for _, task := range rs.Tasks {
+		hashes[task.TaskID] = task.Hash
+
+		a.mu.Lock()
+		path, ok := a.files[task.TaskID]
+		a.mu.Unlock()
+		if !ok {
+			// The task may have been a cache hit, skipped, or canceled
+			// before it ever produced any output.
+			continue
+		}
+
+		contents, err := os.ReadFile(path.ToString())
+		if err != nil {
+			continue
+		}
+		if err := addArchiveEntry(tw, task.TaskID+".log", contents); err != nil {
+			return err
+		}
+	}
+
+	summaryJSON, err := json.Marshal(rs)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal run summary")
+	}
+	if err := addArchiveEntry(tw, "summary.json", summaryJSON); err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(struct {
+		TurboVersion string            `json:"turboVersion"`
+		StartTime    time.Time         `json:"startTime"`
+		EndTime      time.Time         `json:"endTime"`
+		ExitCode     int               `json:"exitCode"`
+		Hashes       map[string]string `json:"hashes"`
+	}{
+		TurboVersion: turboVersion,
+		StartTime:    startAt,
+		EndTime:      time.Now(),
+		ExitCode:     exitCode,
+		Hashes:       hashes,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not marshal run metadata")
+	}
+	return addArchiveEntry(tw, "meta.json", meta)
+}
+
+func addArchiveEntry(tw *tar.Writer, name string, contents []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name: name,
+		Mode: 0644,
+		Size: int64(len(contents)),
+	}); err != nil {
+		return errors.Wrapf(err, "could not write %s entry", name)
+	}
+	if _, err := tw.Write(contents); err != nil {
+		return errors.Wrapf(err, "could not write %s entry", name)
+	}
+	return nil
+}
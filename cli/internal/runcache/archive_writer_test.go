@@ -0,0 +1,20 @@
+package runcache
+
+import "testing"
+
+// TestStagedLogFilename is a regression test for WriterFor calling os.Create
+// directly on a scoped TaskID like "@scope/ui#build": the "/" it carries
+// over from the package name made os.Create fail, since only the staging
+// directory itself -- not an "@scope" subdirectory -- gets MkdirAll'd.
+func TestStagedLogFilename(t *testing.T) {
+	cases := map[string]string{
+		"my-app#build":    "my-app#build.log",
+		"@scope/ui#build": "@scope-ui#build.log",
+		"@a/b/c#lint":     "@a-b-c#lint.log",
+	}
+	for taskID, want := range cases {
+		if got := stagedLogFilename(taskID); got != want {
+			t.Errorf("stagedLogFilename(%q) = %q, want %q", taskID, got, want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package runcache wraps the underlying cache.Cache with the bookkeeping a
+// single task needs around it: where its log output goes, and what to do
+// with it on a hit, a miss, or an error.
+package runcache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"github.com/pkg/errors"
+	"github.com/vercel/turbo/cli/internal/cache"
+	"github.com/vercel/turbo/cli/internal/colorcache"
+	"github.com/vercel/turbo/cli/internal/nodes"
+	"github.com/vercel/turbo/cli/internal/turbopath"
+)
+
+// Opts controls how a RunCache reads from and writes to the underlying
+// cache.
+type Opts struct {
+	SkipReads  bool
+	SkipWrites bool
+}
+
+// RunCache wraps a cache.Cache with everything a run needs to know that
+// isn't specific to the cache implementation itself: where to put a task's
+// log file, and -- if the run is being archived -- where to tee that log's
+// output so it ends up in the archive too.
+type RunCache struct {
+	cache      cache.Cache
+	repoRoot   turbopath.AbsoluteSystemPath
+	opts       Opts
+	colorCache *colorcache.ColorCache
+	archive    *ArchiveWriter
+}
+
+// New returns a RunCache backed by c. archive may be nil, in which case task
+// output is only ever written to its normal log file.
+func New(c cache.Cache, repoRoot turbopath.AbsoluteSystemPath, opts Opts, colorCache *colorcache.ColorCache, archive *ArchiveWriter) *RunCache {
+	return &RunCache{
+		cache:      c,
+		repoRoot:   repoRoot,
+		opts:       opts,
+		colorCache: colorCache,
+		archive:    archive,
+	}
+}
+
+// TaskCache returns the cache entry point for a single task.
+func (rc *RunCache) TaskCache(packageTask *nodes.PackageTask, hash string) *TaskCache {
+	return &TaskCache{
+		rc:          rc,
+		packageTask: packageTask,
+		hash:        hash,
+		logFilePath: rc.repoRoot.UntypedJoin(".turbo", "runs", "logs", hash+".log"),
+	}
+}
+
+// TaskCache is the cache entry point for a single task.
+type TaskCache struct {
+	rc          *RunCache
+	packageTask *nodes.PackageTask
+	hash        string
+	logFilePath turbopath.AbsoluteSystemPath
+
+	// ExpandedOutputs is populated by RestoreOutputs on a cache hit with the
+	// concrete set of files the cache entry restored.
+	ExpandedOutputs []turbopath.AnchoredSystemPath
+}
+
+// RestoreOutputs attempts to restore the task's outputs from cache. hit is
+// false both when the cache was skipped and when it was consulted but came
+// up empty.
+func (tc *TaskCache) RestoreOutputs(ctx context.Context, ui cli.Ui, logger hclog.Logger) (bool, error) {
+	if tc.rc.opts.SkipReads {
+		return false, nil
+	}
+	hit, files, _, err := tc.rc.cache.Fetch(tc.rc.repoRoot, tc.hash, nil)
+	if err != nil {
+		return false, err
+	}
+	if !hit {
+		return false, nil
+	}
+	tc.ExpandedOutputs = files
+	return true, nil
+}
+
+// OutputWriter returns where a task's stdout/stderr should be written: its
+// own log file, teed into the run's log archive if one is configured.
+func (tc *TaskCache) OutputWriter(prettyPrefix string) (io.WriteCloser, error) {
+	if err := tc.logFilePath.EnsureDir(); err != nil {
+		return nil, errors.Wrap(err, "could not create log directory")
+	}
+	logFile, err := os.Create(tc.logFilePath.ToString())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create log file")
+	}
+
+	if tc.rc.archive == nil {
+		return logFile, nil
+	}
+
+	archiveFile, err := tc.rc.archive.WriterFor(tc.packageTask.TaskID)
+	if err != nil {
+		_ = logFile.Close()
+		return nil, err
+	}
+
+	return &teeWriteCloser{w: io.MultiWriter(logFile, archiveFile), closers: []io.Closer{logFile, archiveFile}}, nil
+}
+
+// SaveOutputs saves the task's outputs to the underlying cache once it's
+// finished running.
+func (tc *TaskCache) SaveOutputs(ctx context.Context, logger hclog.Logger, ui cli.Ui, duration int) error {
+	if tc.rc.opts.SkipWrites {
+		return nil
+	}
+	return tc.rc.cache.Put(tc.rc.repoRoot, tc.hash, duration, nil)
+}
+
+// OnError gives the task cache a chance to surface anything useful when a
+// task fails, e.g. replaying its buffered log output.
+func (tc *TaskCache) OnError(ui cli.Ui, logger hclog.Logger) {
+	contents, err := os.ReadFile(tc.logFilePath.ToString())
+	if err != nil {
+		return
+	}
+	ui.Output(fmt.Sprintf("%s", contents))
+}
+
+// teeWriteCloser writes to w (typically an io.MultiWriter) and closes every
+// closer on Close, returning the first error encountered.
+type teeWriteCloser struct {
+	w       io.Writer
+	closers []io.Closer
+}
+
+func (t *teeWriteCloser) Write(p []byte) (int, error) {
+	return t.w.Write(p)
+}
+
+func (t *teeWriteCloser) Close() error {
+	var firstErr error
+	for _, c := range t.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
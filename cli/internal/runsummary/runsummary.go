@@ -0,0 +1,91 @@
+// Package runsummary accumulates the result of every task in a run, backing
+// `--summarize` output and the run-logs archive.
+package runsummary
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/vercel/turbo/cli/internal/turbopath"
+)
+
+// TargetState describes how a task's execution ended up.
+type TargetState string
+
+const (
+	// TargetCached means the task's outputs were restored from cache instead
+	// of being executed.
+	TargetCached TargetState = "cached"
+	// TargetBuilt means the task ran to completion successfully.
+	TargetBuilt TargetState = "built"
+	// TargetBuildFailed means the task ran and exited with an error.
+	TargetBuildFailed TargetState = "build_failed"
+	// TargetTimedOut means the task exceeded its configured timeout and was
+	// terminated, as distinct from a task that ran and failed on its own.
+	TargetTimedOut TargetState = "timed_out"
+	// TargetCanceled means the task was stopped because the run was shutting
+	// down, as distinct from a task that failed or timed out on its own: it
+	// was still queued when scheduling stopped (the first Ctrl-C), its
+	// command could not start because the process manager was already
+	// closing, or the context it ran under was canceled. A task whose
+	// running command is killed by the process manager closing is recorded
+	// as TargetBuildFailed, not TargetCanceled.
+	TargetCanceled TargetState = "canceled"
+)
+
+// TaskExecutionSummary records how a single task's execution went.
+type TaskExecutionSummary struct {
+	State     TargetState `json:"state"`
+	Error     string      `json:"error,omitempty"`
+	StartTime time.Time   `json:"startTime"`
+	EndTime   time.Time   `json:"endTime"`
+}
+
+// TaskSummary is everything the run summary records about a single task.
+type TaskSummary struct {
+	TaskID          string                `json:"taskId"`
+	Hash            string                `json:"hash"`
+	Execution       *TaskExecutionSummary `json:"execution,omitempty"`
+	ExpandedOutputs []string              `json:"expandedOutputs,omitempty"`
+}
+
+// RunSummary accumulates the result of every task in a run.
+type RunSummary struct {
+	ID    string         `json:"id"`
+	Tasks []*TaskSummary `json:"tasks"`
+}
+
+// TrackTask starts tracking a task's execution and returns a function that
+// must be called exactly once, when the task reaches a terminal state.
+func (rs *RunSummary) TrackTask(taskID string) (func(state TargetState, err error), *TaskExecutionSummary) {
+	summary := &TaskExecutionSummary{StartTime: time.Now()}
+	tracer := func(state TargetState, err error) {
+		summary.State = state
+		summary.EndTime = time.Now()
+		if err != nil {
+			summary.Error = err.Error()
+		}
+	}
+	return tracer, summary
+}
+
+// Close prints a one-line recap of the run.
+func (rs *RunSummary) Close(ui cli.Ui) {
+	ui.Output(fmt.Sprintf("Ran %d task(s)", len(rs.Tasks)))
+}
+
+// Save writes the run summary to .turbo/runs/<id>/summary.json.
+func (rs *RunSummary) Save(repoRoot turbopath.AbsoluteSystemPath, singlePackage bool) error {
+	path := repoRoot.UntypedJoin(".turbo", "runs", rs.ID, "summary.json")
+	if err := path.EnsureDir(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.ToString(), data, 0644)
+}